@@ -0,0 +1,98 @@
+package kloudinary
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/api/uploader"
+)
+
+// Backend is implemented by every storage provider AssetUploadManager can
+// delegate to.
+type Backend interface {
+	// Upload stores file (a local file path or an io.Reader) under the
+	// target described by params and returns the resulting asset metadata.
+	Upload(ctx context.Context, file interface{}, params uploader.UploadParams) (*uploader.UploadResult, error)
+
+	// Destroy permanently removes the asset identified by publicID.
+	Destroy(ctx context.Context, publicID string, resourceType string) (*uploader.DestroyResult, error)
+
+	// Transform returns a delivery URL for publicID with t applied.
+	Transform(ctx context.Context, publicID string, resourceType string, t Transformation) (string, error)
+
+	// SignedURL returns a time-limited delivery URL for publicID with t
+	// applied, for use when the underlying asset is private.
+	SignedURL(ctx context.Context, publicID string, resourceType string, t Transformation, expiresAt time.Time) (string, error)
+}
+
+// Option configures an AssetUploadManager created via
+// NewAssetUploadManagerWithBackend.
+type Option func(*AssetUploadManager)
+
+// WithMaxAssetSize overrides the default maximum asset size (in bytes).
+func WithMaxAssetSize(size int64) Option {
+	return func(am *AssetUploadManager) { am.MaxAssetSize = size }
+}
+
+// WithMaxUploadTimeout overrides the default per-upload timeout.
+func WithMaxUploadTimeout(timeout time.Duration) Option {
+	return func(am *AssetUploadManager) { am.MaxUploadTimeout = timeout }
+}
+
+// WithMaxNumberOfConcurrentUploads overrides the default upload concurrency.
+func WithMaxNumberOfConcurrentUploads(n int64) Option {
+	return func(am *AssetUploadManager) { am.MaxNumberOfConcurrentUploads = n }
+}
+
+// WithHashAlgorithms overrides the digests computed while streaming uploads.
+func WithHashAlgorithms(algorithms ...string) Option {
+	return func(am *AssetUploadManager) { am.HashAlgorithms = algorithms }
+}
+
+// WithSessionStore overrides the store used for resumable upload sessions.
+func WithSessionStore(store SessionStore) Option {
+	return func(am *AssetUploadManager) { am.SessionStore = store }
+}
+
+// WithRetryPolicy overrides the backoff schedule applied around each upload
+// attempt. The zero value disables retrying.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(am *AssetUploadManager) { am.RetryPolicy = policy }
+}
+
+// WithProgressFunc registers a callback invoked as bytes are streamed to the
+// backend during an upload.
+func WithProgressFunc(fn ProgressFunc) Option {
+	return func(am *AssetUploadManager) { am.ProgressFunc = fn }
+}
+
+// NewAssetUploadManagerWithBackend creates an AssetUploadManager backed by
+// an arbitrary Backend, for providers other than Cloudinary or for tests
+// that want to avoid network calls.
+func NewAssetUploadManagerWithBackend(backend Backend, opts ...Option) (*AssetUploadManager, error) {
+	if backend == nil {
+		return nil, errors.New("backend must not be nil")
+	}
+
+	am := new(AssetUploadManager)
+
+	am.FileTypeSupported = make([]string, 1)
+	am.FileTypeSupported = append(am.FileTypeSupported, imageExtensions...)
+	am.FileTypeSupported = append(am.FileTypeSupported, audioExtensions...)
+	am.FileTypeSupported = append(am.FileTypeSupported, videoExtensions...)
+	am.FileTypeSupported = append(am.FileTypeSupported, documentExtensions...)
+
+	am.MaxAssetSize = 4 * 1024 * 1024 // Max size of 4 MB by default
+	am.Metadata = Meta{}
+	am.MaxNumberOfConcurrentUploads = 1
+	am.MaxUploadTimeout = 1 * time.Minute
+
+	am.backend = backend
+
+	for _, opt := range opts {
+		opt(am)
+	}
+
+	return am, nil
+}
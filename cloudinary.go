@@ -6,9 +6,12 @@ package kloudinary
 // base on the mimetype of the file being uploaded
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"hash"
 	"io"
+	"mime"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -16,7 +19,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/cloudinary/cloudinary-go"
 	"github.com/cloudinary/cloudinary-go/api"
 	"github.com/cloudinary/cloudinary-go/api/uploader"
 	"github.com/google/uuid"
@@ -105,6 +107,9 @@ type FileUploadResult struct {
 	err     error
 	result  *uploader.UploadResult
 	latency time.Duration
+
+	Hashes map[string]string // digests computed while streaming, keyed by algorithm name
+	Size   int64             // exact number of bytes streamed to the backend
 }
 
 // AssetUploadManager wraps around cloudinary upload package,
@@ -113,51 +118,33 @@ type FileUploadResult struct {
 // base on the mimetype of the file being uploaded
 type AssetUploadManager struct {
 	FileTypeSupported            []string      // supported file types
-	MaxAssetSize                 int64         // maximum size for an asset
+	MaxAssetSize                 int64         // maximum asset size in bytes, enforced against the streamed byte count
 	MaxUploadTimeout             time.Duration // maximum upload timeout for assets to be uploaded
 	MaxNumberOfConcurrentUploads int64         // number of concurrent uploads
 	Metadata                     Meta          // metadata about asset upload management for this instance
+	SessionStore                 SessionStore  // persists resumable upload sessions; defaults to an in-memory store
+	HashAlgorithms               []string      // digests to compute while streaming each upload; defaults to md5, sha1, sha256, sha512
+	MimePolicy                   MimePolicy    // governs which sniffed MIME types are accepted; zero value imposes no restriction
+	RetryPolicy                  RetryPolicy   // retry/backoff applied around each upload attempt; zero value disables retrying
+	ProgressFunc                 ProgressFunc  // called as bytes are streamed to the backend; nil disables progress reporting
 
-	cld *cloudinary.Cloudinary
+	backend Backend
 }
 
 // NewAssetUploadManager New creates a new asset uploader which will upload files and other supported
 // assets to cloudinary server. Inorder, for this to work, you need to configure
 // AssetUploadManager with the following configuration `cloudName`, `apikey`,and `apiSecret`
 // for more information visit: https://cloudinary.com/documentation/
+//
+// This is a convenience wrapper around NewAssetUploadManagerWithBackend for
+// the common case of uploading straight to Cloudinary.
 func NewAssetUploadManager(cloudName string, apiKey string, apiSecret string) (*AssetUploadManager, error) {
-
-	//  create a new asset upload manager instance
-	//  Note:  go can decide whether it is necessary to put it
-	//  on the heap or stack
-	am := new(AssetUploadManager)
-
-	am.FileTypeSupported = make([]string, 1)
-
-	// set up default file support for uploaded files
-	am.FileTypeSupported = append(am.FileTypeSupported, imageExtensions...)
-	am.FileTypeSupported = append(am.FileTypeSupported, audioExtensions...)
-	am.FileTypeSupported = append(am.FileTypeSupported, videoExtensions...)
-	am.FileTypeSupported = append(am.FileTypeSupported, documentExtensions...)
-
-	am.MaxAssetSize = 1024 * 4 // Max size of 4 mb by default
-
-	am.Metadata = Meta{}                  // metadata to store on each asset manger configuration
-	am.MaxNumberOfConcurrentUploads = 1   // default to single
-	am.MaxUploadTimeout = 1 * time.Minute // maximum upload timeout for push requests to cloudinary server
-
-	cld, err := cloudinary.NewFromParams(
-		cloudName,
-		apiKey,
-		apiSecret,
-	)
-
+	backend, err := NewCloudinaryBackend(cloudName, apiKey, apiSecret)
 	if err != nil {
 		return nil, err
 	}
 
-	am.cld = cld
-	return am, nil
+	return NewAssetUploadManagerWithBackend(backend)
 }
 
 // isFileSupported returns true if the asset to be uploaded is supported by the
@@ -179,7 +166,15 @@ func (am *AssetUploadManager) getLogicalFolderBasedOnExtension(extension string)
 // UploadSingleFile is used to upload a single file to the server
 // the file can either be a byte slice or a string
 func (am *AssetUploadManager) UploadSingleFile(ctx context.Context, file interface{}) (*uploader.UploadResult, error) {
+	result, _, err := am.uploadSingleFile(ctx, file)
+	return result, err
+}
 
+// uploadSingleFile is the shared implementation behind UploadSingleFile and
+// UploadMultipleFiles; it additionally returns the uploadMeta (digests, exact
+// byte count) computed while streaming so UploadMultipleFiles can attach it
+// to a FileUploadResult.
+func (am *AssetUploadManager) uploadSingleFile(ctx context.Context, file interface{}) (*uploader.UploadResult, uploadMeta, error) {
 	value := reflect.TypeOf(file)
 
 	switch value.Kind() {
@@ -192,105 +187,161 @@ func (am *AssetUploadManager) UploadSingleFile(ctx context.Context, file interfa
 		// and assert the mimetype of the file
 		file, ok := file.(io.Reader)
 		if !ok {
-			return nil, errors.New("data type not supported for interface")
+			return nil, uploadMeta{}, errors.New("data type not supported for interface")
 		}
 		return am.upload(ctx, file)
 	}
 }
 
-// TransformImage is used to transform image property of a single file on a cloudinary server
-func (am *AssetUploadManager) TransformImage(ctx context.Context, publicId string, transformation string) (string, error) {
-	img, err := am.cld.Image(publicId)
-
-	if err != nil {
-		return "", err
-	}
-
-	img.Transformation = transformation
+// TransformImage returns a delivery URL for the image identified by publicId
+// with t applied.
+func (am *AssetUploadManager) TransformImage(ctx context.Context, publicId string, t Transformation) (string, error) {
+	return am.backend.Transform(ctx, publicId, "image", t)
+}
 
-	// Generate the delivery URL
-	url, err := img.String()
+// TransformVideo returns a delivery URL for the video identified by publicId
+// with t applied.
+func (am *AssetUploadManager) TransformVideo(ctx context.Context, publicId string, t Transformation) (string, error) {
+	return am.backend.Transform(ctx, publicId, "video", t)
+}
 
-	if err != nil {
-		return "", err
-	}
+// TransformRaw returns a delivery URL for the raw/other asset identified by
+// publicId with t applied.
+func (am *AssetUploadManager) TransformRaw(ctx context.Context, publicId string, t Transformation) (string, error) {
+	return am.backend.Transform(ctx, publicId, "raw", t)
+}
 
-	return url, nil
+// SignedAssetURL returns a time-limited delivery URL for publicId with t
+// applied, valid until expiresAt. Use this instead of TransformImage/Video/Raw
+// when the underlying asset is private.
+func (am *AssetUploadManager) SignedAssetURL(ctx context.Context, publicId string, resourceType string, t Transformation, expiresAt time.Time) (string, error) {
+	return am.backend.SignedURL(ctx, publicId, resourceType, t, expiresAt)
 }
 
 func (am *AssetUploadManager) DestroyAsset(ctx context.Context, publicId string, transformation string) (*uploader.DestroyResult, error) {
-	return am.cld.Upload.Destroy(
-		ctx,
-		uploader.DestroyParams{
-			PublicID: publicId,
-		},
-	)
-
+	return am.backend.Destroy(ctx, publicId, "")
 }
 
+// UploadMultipleFiles uploads every file concurrently, bounded by
+// MaxNumberOfConcurrentUploads, and returns once all of them have completed
+// or failed. Each upload runs under ctx with MaxUploadTimeout applied, so
+// cancelling ctx cancels every upload still in flight.
 func (am *AssetUploadManager) UploadMultipleFiles(ctx context.Context, files ...interface{}) []FileUploadResult {
+	concurrency := am.MaxNumberOfConcurrentUploads
+	if concurrency <= 0 {
+		concurrency = 1
+	}
 
 	var (
-		ret       = make([]FileUploadResult, 0)
-		uploads   = make(chan FileUploadResult, len(files)) //  upload retrieval channel
-		semaphore = make(chan struct{}, am.MaxNumberOfConcurrentUploads)
-		wg        = sync.WaitGroup{}
+		ret       = make([]FileUploadResult, 0, len(files))
+		uploads   = make(chan FileUploadResult, len(files))
+		semaphore = make(chan struct{}, concurrency)
+		wg        sync.WaitGroup
 	)
 
-	wg.Add(1)
-	go func(wg *sync.WaitGroup, ret *[]FileUploadResult, uploads chan FileUploadResult) {
-		defer wg.Done()
-		for upload := range uploads {
-			*ret = append(*ret, upload)
-		}
-	}(&wg, &ret, uploads)
-
-	for id, file := range files {
+	for _, file := range files {
 		semaphore <- struct{}{}
-		go func(id int, file interface{}) {
-			defer func(id int, semaphore chan struct{}) {
-				<-semaphore
-				if id == len(files)-1 {
-					close(uploads)
-					close(semaphore)
-				}
-			}(id, semaphore)
+		wg.Add(1)
+		go func(file interface{}) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
 
 			start := time.Now()
-			ctx, cancel := context.WithTimeout(context.Background(), am.MaxUploadTimeout)
+			uploadCtx, cancel := context.WithTimeout(ctx, am.MaxUploadTimeout)
 			defer cancel()
-			result, err := am.UploadSingleFile(ctx, file)
-			finish := time.Since(start)
-			uploads <- FileUploadResult{file: file, err: err, result: result, latency: finish}
 
-		}(id, file)
+			result, meta, err := am.uploadSingleFile(uploadCtx, file)
+			uploads <- FileUploadResult{
+				file: file, err: err, result: result, latency: time.Since(start),
+				Hashes: meta.hashes, Size: meta.size,
+			}
+		}(file)
+	}
+
+	go func() {
+		wg.Wait()
+		close(uploads)
+	}()
+
+	for result := range uploads {
+		ret = append(ret, result)
 	}
 
-	wg.Wait()
 	return ret
 }
 
-func (am *AssetUploadManager) upload(ctx context.Context, file io.Reader) (*uploader.UploadResult, error) {
-	head := make([]byte, 261)
-	_, err := file.Read(head)
-	if err != nil {
-		return nil, err
+func (am *AssetUploadManager) upload(ctx context.Context, file io.Reader) (*uploader.UploadResult, uploadMeta, error) {
+	br := bufio.NewReaderSize(file, mimeSniffLen)
+	head, err := br.Peek(mimeSniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, uploadMeta{}, err
 	}
 
 	kind, _ := filetype.Match(head)
 
-	return am.cld.Upload.Upload(
-		ctx,
-		file,
-		uploader.UploadParams{
-			PublicID: uuid.NewString(),
-			Folder:   am.getLogicalFolderBasedOnExtension(kind.Extension),
-			Metadata: api.Metadata(am.Metadata),
-		},
-	)
+	detected := detectMimeType(kind, "")
+	if err := am.MimePolicy.check("", detected); err != nil {
+		return nil, uploadMeta{}, err
+	}
+
+	publicID := uuid.NewString()
+	hashers, hashWriter := newHasherSet(am.hashAlgorithms())
+	counted := &countingReader{r: br, max: am.MaxAssetSize}
+	if am.ProgressFunc != nil {
+		counted.onProgress = func(n int64) { am.ProgressFunc(n, 0, publicID) }
+	}
+	tee := io.TeeReader(counted, hashWriter)
+
+	// Retrying requires re-reading file from the start, which is only
+	// possible when it implements io.Seeker; otherwise the stream is
+	// already partially consumed after a failed attempt, so only one
+	// attempt is made regardless of the configured policy.
+	seeker, seekable := file.(io.Seeker)
+	policy := am.retryPolicy()
+	if !seekable {
+		policy.MaxAttempts = 1
+	}
+
+	var result *uploader.UploadResult
+	err = policy.run(ctx, func(attempt int) error {
+		if attempt > 0 {
+			if _, serr := seeker.Seek(0, io.SeekStart); serr != nil {
+				return serr
+			}
+			for _, h := range hashers {
+				h.Reset()
+			}
+			br = bufio.NewReaderSize(file, mimeSniffLen)
+			counted = &countingReader{r: br, max: am.MaxAssetSize}
+			if am.ProgressFunc != nil {
+				counted.onProgress = func(n int64) { am.ProgressFunc(n, 0, publicID) }
+			}
+			tee = io.TeeReader(counted, hashWriter)
+		}
+
+		res, uerr := am.backend.Upload(
+			ctx,
+			tee,
+			uploader.UploadParams{
+				PublicID: publicID,
+				Folder:   am.getLogicalFolderBasedOnExtension(kind.Extension),
+				Metadata: api.Metadata(am.Metadata),
+			},
+		)
+		if uerr != nil {
+			return uerr
+		}
+		result = res
+		return nil
+	})
+	if err != nil {
+		return nil, uploadMeta{}, err
+	}
+
+	return result, uploadMeta{hashes: sumHashes(hashers), size: counted.n}, nil
 }
 
-func (am *AssetUploadManager) uploadBasedOnFilePath(ctx context.Context, file interface{}) (*uploader.UploadResult, error) {
+func (am *AssetUploadManager) uploadBasedOnFilePath(ctx context.Context, file interface{}) (*uploader.UploadResult, uploadMeta, error) {
 	f := file.(string)
 
 	base := filepath.Base(f)
@@ -298,32 +349,74 @@ func (am *AssetUploadManager) uploadBasedOnFilePath(ctx context.Context, file in
 	extension := strings.TrimPrefix(filepath.Ext(f), ".")
 
 	if !am.isFileSupported(extension) {
-		return nil, errors.New("invalid MIME type")
+		return nil, uploadMeta{}, errors.New("invalid MIME type")
 	}
 
-	stat, err := os.Lstat(file.(string))
+	var (
+		result  *uploader.UploadResult
+		hashers map[string]hash.Hash
+		size    int64
+	)
+
+	// Each attempt reopens f from scratch, so unlike the io.Reader path in
+	// upload(), path-based uploads can always be retried in full.
+	err := am.retryPolicy().run(ctx, func(attempt int) error {
+		fh, err := os.Open(f)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
 
-	if err != nil {
-		return nil, err
-	}
+		var total int64
+		if stat, serr := fh.Stat(); serr == nil {
+			total = stat.Size()
+		}
 
-	// Convert file size from bytes to megabytes
-	sizeInMB := float64(stat.Size()) / (1024 * 1024)
+		br := bufio.NewReaderSize(fh, mimeSniffLen)
+		head, err := br.Peek(mimeSniffLen)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return err
+		}
+
+		kind, _ := filetype.Match(head)
+		declared := mime.TypeByExtension("." + extension)
+		detected := detectMimeType(kind, extension)
+		if err := am.MimePolicy.check(declared, detected); err != nil {
+			return err
+		}
 
-	// Check if the file size exceeds the maximum allowed size in megabytes
-	if sizeInMB > float64(am.MaxAssetSize) {
-		if !(stat.Mode().IsDir() || stat.Mode().IsRegular()) {
-			return nil, errors.New("max asset size exceeded")
+		// Hash and transfer in the same pass: tee the bytes the backend
+		// reads off of fh into the hashers, rather than hashing the whole
+		// file first and then handing the backend the path to re-open and
+		// re-read it a second time. This sends the asset as a single
+		// request rather than the SDK's own large-file chunking, which only
+		// kicks in for a path argument; assets that need chunked, resumable
+		// transfer should go through CreateUploadSession/AppendChunk instead.
+		attemptHashers, hashWriter := newHasherSet(am.hashAlgorithms())
+		counted := &countingReader{r: br, max: am.MaxAssetSize}
+		if am.ProgressFunc != nil {
+			counted.onProgress = func(n int64) { am.ProgressFunc(n, total, base) }
+		}
+		tee := io.TeeReader(counted, hashWriter)
+
+		res, err := am.backend.Upload(
+			ctx, tee,
+			uploader.UploadParams{
+				PublicID: base,
+				Folder:   am.getLogicalFolderBasedOnExtension(extension),
+				Metadata: api.Metadata(am.Metadata),
+			},
+		)
+		if err != nil {
+			return err
 		}
+
+		result, hashers, size = res, attemptHashers, counted.n
+		return nil
+	})
+	if err != nil {
+		return nil, uploadMeta{}, err
 	}
 
-	f, _ = file.(string)
-	return am.cld.Upload.Upload(
-		ctx, f,
-		uploader.UploadParams{
-			PublicID: base,
-			Folder:   am.getLogicalFolderBasedOnExtension(extension),
-			Metadata: api.Metadata(am.Metadata),
-		},
-	)
+	return result, uploadMeta{hashes: sumHashes(hashers), size: size}, nil
 }
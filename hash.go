@@ -0,0 +1,110 @@
+package kloudinary
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// defaultHashAlgorithms is used when AssetUploadManager.HashAlgorithms is unset.
+var defaultHashAlgorithms = []string{"md5", "sha1", "sha256", "sha512"}
+
+// ErrMaxAssetSizeExceeded is returned once a streamed upload has read more
+// than Max bytes, replacing the old os.Lstat-only size check that silently
+// let io.Reader inputs through.
+type ErrMaxAssetSizeExceeded struct {
+	Max int64
+}
+
+func (e ErrMaxAssetSizeExceeded) Error() string {
+	return fmt.Sprintf("asset exceeds the maximum allowed size of %d bytes", e.Max)
+}
+
+// ProgressFunc reports upload progress as bytes are streamed to the backend:
+// uploaded is the cumulative byte count streamed so far for publicID, and
+// total is the asset's full size in bytes, or 0 when it cannot be known
+// ahead of time (an io.Reader upload with no declared length).
+type ProgressFunc func(uploaded, total int64, publicID string)
+
+// countingReader wraps an io.Reader, tracking the exact number of bytes read
+// and failing with ErrMaxAssetSizeExceeded as soon as Max is crossed, so the
+// limit is enforced on what is actually streamed rather than on a pre-upload
+// stat. If onProgress is set, it is invoked with the running byte count after
+// every successful read.
+type countingReader struct {
+	r          io.Reader
+	max        int64
+	n          int64
+	onProgress func(n int64)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if n > 0 && c.onProgress != nil {
+		c.onProgress(c.n)
+	}
+	if c.max > 0 && c.n > c.max {
+		return n, ErrMaxAssetSizeExceeded{Max: c.max}
+	}
+	return n, err
+}
+
+// uploadMeta carries the streamed digests and byte count computed alongside
+// an upload, so callers that want them (UploadMultipleFiles) can attach them
+// to a FileUploadResult while UploadSingleFile stays source-compatible.
+type uploadMeta struct {
+	hashes map[string]string
+	size   int64
+}
+
+// hashAlgorithms returns the digests to compute for each upload, falling
+// back to defaultHashAlgorithms when none are configured.
+func (am *AssetUploadManager) hashAlgorithms() []string {
+	if len(am.HashAlgorithms) == 0 {
+		return defaultHashAlgorithms
+	}
+	return am.HashAlgorithms
+}
+
+// newHasherSet builds one hash.Hash per requested algorithm along with an
+// io.Writer that fans incoming bytes out to all of them; unknown algorithm
+// names are ignored.
+func newHasherSet(algorithms []string) (map[string]hash.Hash, io.Writer) {
+	hashers := make(map[string]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+
+	for _, alg := range algorithms {
+		var h hash.Hash
+		switch alg {
+		case "md5":
+			h = md5.New()
+		case "sha1":
+			h = sha1.New()
+		case "sha256":
+			h = sha256.New()
+		case "sha512":
+			h = sha512.New()
+		default:
+			continue
+		}
+		hashers[alg] = h
+		writers = append(writers, h)
+	}
+
+	return hashers, io.MultiWriter(writers...)
+}
+
+// sumHashes renders the final digest of every hasher as a hex string.
+func sumHashes(hashers map[string]hash.Hash) map[string]string {
+	sums := make(map[string]string, len(hashers))
+	for alg, h := range hashers {
+		sums[alg] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums
+}
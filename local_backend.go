@@ -0,0 +1,101 @@
+package kloudinary
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/api/uploader"
+	"github.com/google/uuid"
+)
+
+// LocalBackend is the Backend implementation that writes assets under Root
+// on the local filesystem, mirroring the folder layout CloudinaryBackend
+// uses (see getLogicalFolderBasedOnExtension).
+type LocalBackend struct {
+	Root string
+}
+
+// NewLocalBackend creates a LocalBackend rooted at root, creating it if it
+// does not already exist.
+func NewLocalBackend(root string) (*LocalBackend, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalBackend{Root: root}, nil
+}
+
+func (b *LocalBackend) Upload(ctx context.Context, file interface{}, params uploader.UploadParams) (*uploader.UploadResult, error) {
+	var r io.Reader
+	switch v := file.(type) {
+	case string:
+		fh, err := os.Open(v)
+		if err != nil {
+			return nil, err
+		}
+		defer fh.Close()
+		r = fh
+	case io.Reader:
+		r = v
+	default:
+		return nil, errors.New("local backend: unsupported file type")
+	}
+
+	folder := params.Folder
+	if folder == "" {
+		folder = "others"
+	}
+	dir := filepath.Join(b.Root, folder)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	publicID := params.PublicID
+	if publicID == "" {
+		publicID = uuid.NewString()
+	}
+	dest := filepath.Join(dir, publicID)
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+
+	written, err := io.Copy(out, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &uploader.UploadResult{
+		PublicID:  publicID,
+		Bytes:     int(written),
+		URL:       "file://" + dest,
+		SecureURL: "file://" + dest,
+	}, nil
+}
+
+func (b *LocalBackend) Destroy(ctx context.Context, publicID string, resourceType string) (*uploader.DestroyResult, error) {
+	matches, err := filepath.Glob(filepath.Join(b.Root, "*", publicID))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return &uploader.DestroyResult{Result: "not found"}, nil
+	}
+	if err := os.Remove(matches[0]); err != nil {
+		return nil, err
+	}
+	return &uploader.DestroyResult{Result: "ok"}, nil
+}
+
+func (b *LocalBackend) Transform(ctx context.Context, publicID string, resourceType string, t Transformation) (string, error) {
+	return "", errors.New("local backend: transformations are not supported")
+}
+
+func (b *LocalBackend) SignedURL(ctx context.Context, publicID string, resourceType string, t Transformation, expiresAt time.Time) (string, error) {
+	return "", errors.New("local backend: signed URLs are not supported")
+}
@@ -0,0 +1,84 @@
+package kloudinary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/api/uploader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalBackend_UploadWritesUnderFolder(t *testing.T) {
+	root := t.TempDir()
+	backend, err := NewLocalBackend(root)
+	assert.NoError(t, err)
+
+	result, err := backend.Upload(context.Background(), strings.NewReader("hello"), uploader.UploadParams{
+		PublicID: "greeting",
+		Folder:   "texts",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Bytes)
+
+	data, err := os.ReadFile(filepath.Join(root, "texts", "greeting"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestLocalBackend_UploadDefaultsFolderAndPublicID(t *testing.T) {
+	root := t.TempDir()
+	backend, err := NewLocalBackend(root)
+	assert.NoError(t, err)
+
+	result, err := backend.Upload(context.Background(), strings.NewReader("x"), uploader.UploadParams{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.PublicID)
+
+	_, err = os.Stat(filepath.Join(root, "others", result.PublicID))
+	assert.NoError(t, err)
+}
+
+func TestLocalBackend_DestroyRemovesUploadedAsset(t *testing.T) {
+	root := t.TempDir()
+	backend, err := NewLocalBackend(root)
+	assert.NoError(t, err)
+
+	_, err = backend.Upload(context.Background(), strings.NewReader("x"), uploader.UploadParams{
+		PublicID: "to-delete",
+		Folder:   "texts",
+	})
+	assert.NoError(t, err)
+
+	destroyResult, err := backend.Destroy(context.Background(), "to-delete", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", destroyResult.Result)
+
+	_, err = os.Stat(filepath.Join(root, "texts", "to-delete"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalBackend_DestroyNotFound(t *testing.T) {
+	root := t.TempDir()
+	backend, err := NewLocalBackend(root)
+	assert.NoError(t, err)
+
+	destroyResult, err := backend.Destroy(context.Background(), "missing", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "not found", destroyResult.Result)
+}
+
+func TestLocalBackend_TransformAndSignedURLUnsupported(t *testing.T) {
+	root := t.TempDir()
+	backend, err := NewLocalBackend(root)
+	assert.NoError(t, err)
+
+	_, err = backend.Transform(context.Background(), "x", "", NewTransformation())
+	assert.Error(t, err)
+
+	_, err = backend.SignedURL(context.Background(), "x", "", NewTransformation(), time.Now())
+	assert.Error(t, err)
+}
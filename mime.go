@@ -0,0 +1,71 @@
+package kloudinary
+
+import (
+	"fmt"
+	"mime"
+
+	"github.com/h2non/filetype"
+	"github.com/h2non/filetype/types"
+	"golang.org/x/exp/slices"
+)
+
+// mimeSniffLen is the number of leading bytes inspected by filetype.Match,
+// matching the header size most container/magic-byte formats need to be
+// identified unambiguously.
+const mimeSniffLen = 512
+
+// MimePolicy governs which MIME types AssetUploadManager accepts for an
+// upload, on top of the broader FileTypeSupported extension check. The zero
+// value imposes no restriction: nothing is denied, nothing requires an
+// allow-list match, and a mismatched extension is not rejected.
+type MimePolicy struct {
+	Allow             []string // MIME types that are accepted; empty means any type not denied is accepted
+	Deny              []string // MIME types that are always rejected, checked before Allow
+	RequireMagicMatch bool     // when true, the extension's implied MIME type must agree with the sniffed one
+}
+
+// ErrMimeMismatch is returned when an upload is rejected by MimePolicy,
+// either because the sniffed type is denied/not allow-listed or because it
+// disagrees with the type implied by the file's extension under
+// RequireMagicMatch.
+type ErrMimeMismatch struct {
+	Declared string // MIME type implied by the file's extension, if known
+	Detected string // MIME type sniffed from the file's magic bytes
+}
+
+func (e ErrMimeMismatch) Error() string {
+	return fmt.Sprintf("mime mismatch: declared %q but detected %q", e.Declared, e.Detected)
+}
+
+// detectMimeType resolves the MIME type sniffed from a file's magic bytes
+// (kind, as already matched by the caller via filetype.Match), falling back
+// to mime.TypeByExtension against extension when the magic bytes are
+// inconclusive (e.g. plain-text formats filetype.Match does not recognize).
+func detectMimeType(kind types.Type, extension string) string {
+	if kind != filetype.Unknown {
+		return kind.MIME.Value
+	}
+	if extension == "" {
+		return ""
+	}
+	return mime.TypeByExtension("." + extension)
+}
+
+// check validates detected against the policy, returning ErrMimeMismatch on
+// any violation. detected == "" (fully inconclusive) is always allowed,
+// since there is nothing to enforce against.
+func (p MimePolicy) check(declared, detected string) error {
+	if detected == "" {
+		return nil
+	}
+	if slices.Contains(p.Deny, detected) {
+		return ErrMimeMismatch{Declared: declared, Detected: detected}
+	}
+	if len(p.Allow) > 0 && !slices.Contains(p.Allow, detected) {
+		return ErrMimeMismatch{Declared: declared, Detected: detected}
+	}
+	if p.RequireMagicMatch && declared != "" && declared != detected {
+		return ErrMimeMismatch{Declared: declared, Detected: detected}
+	}
+	return nil
+}
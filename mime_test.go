@@ -0,0 +1,43 @@
+package kloudinary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMimePolicy_ZeroValueAllowsEverything(t *testing.T) {
+	var p MimePolicy
+	assert.NoError(t, p.check("image/png", "image/png"))
+	assert.NoError(t, p.check("image/png", "application/zip"))
+	assert.NoError(t, p.check("", ""))
+}
+
+func TestMimePolicy_Deny(t *testing.T) {
+	p := MimePolicy{Deny: []string{"application/zip"}}
+	assert.NoError(t, p.check("", "image/png"))
+
+	err := p.check("", "application/zip")
+	assert.Error(t, err)
+	assert.Equal(t, ErrMimeMismatch{Declared: "", Detected: "application/zip"}, err)
+}
+
+func TestMimePolicy_Allow(t *testing.T) {
+	p := MimePolicy{Allow: []string{"image/png", "image/jpeg"}}
+	assert.NoError(t, p.check("", "image/png"))
+	assert.Error(t, p.check("", "application/zip"))
+}
+
+func TestMimePolicy_DenyTakesPrecedenceOverAllow(t *testing.T) {
+	p := MimePolicy{Allow: []string{"image/png"}, Deny: []string{"image/png"}}
+	assert.Error(t, p.check("", "image/png"))
+}
+
+func TestMimePolicy_RequireMagicMatch(t *testing.T) {
+	p := MimePolicy{RequireMagicMatch: true}
+	assert.NoError(t, p.check("image/png", "image/png"))
+	assert.Error(t, p.check("image/png", "application/zip"))
+
+	// an unknown declared type has nothing to disagree with
+	assert.NoError(t, p.check("", "image/png"))
+}
@@ -0,0 +1,372 @@
+package kloudinary
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/api/uploader"
+	"github.com/google/uuid"
+)
+
+// CloudinaryChunkTransport is implemented by backends that can support the
+// resumable upload flow in this file, which relies on Cloudinary-specific
+// chunked upload semantics (X-Unique-Upload-Id and signed form parameters)
+// that have no equivalent on other backends.
+type CloudinaryChunkTransport interface {
+	chunkUploadURL() string
+	chunkSignParams(params uploader.UploadParams) (url.Values, error)
+}
+
+// ErrOffsetMismatch is returned by AppendChunk when the offset supplied by the
+// caller does not match the offset recorded against the session, so the
+// caller can re-seek its reader to Expected and retry.
+type ErrOffsetMismatch struct {
+	Expected int64
+}
+
+func (e ErrOffsetMismatch) Error() string {
+	return fmt.Sprintf("upload offset mismatch: expected %d", e.Expected)
+}
+
+// ErrSessionExpired is returned once MaxUploadTimeout has elapsed since a
+// session was created, so the caller knows to start a new session.
+var ErrSessionExpired = errors.New("upload session expired")
+
+// UploadSessionMeta describes the asset a resumable upload session is for.
+type UploadSessionMeta struct {
+	PublicID string // target public ID, defaults to a generated uuid when empty
+	Folder   string // logical folder, usually from getLogicalFolderBasedOnExtension
+	Size     int64  // total size of the asset in bytes
+}
+
+// UploadSession tracks the progress of a single resumable, chunked upload.
+// It is what a SessionStore persists between process restarts.
+type UploadSession struct {
+	ID          string
+	Meta        UploadSessionMeta
+	UploadID    string // Cloudinary's X-Unique-Upload-Id for this session
+	Offset      int64
+	MD5State    []byte // marshaled rolling md5.Hash
+	Sha256State []byte // marshaled rolling sha256.Hash
+	CreatedAt   time.Time
+
+	// Result is the raw response body returned by the chunk that completed
+	// the upload (the one whose Content-Range reached Meta.Size), which
+	// Cloudinary's chunked upload protocol returns as the finished asset's
+	// full UploadResult JSON. FinalizeUpload decodes this rather than
+	// re-deriving the result through a separate API call.
+	Result json.RawMessage
+}
+
+func newUploadSession(meta UploadSessionMeta) *UploadSession {
+	if meta.PublicID == "" {
+		meta.PublicID = uuid.NewString()
+	}
+	return &UploadSession{
+		ID:        uuid.NewString(),
+		Meta:      meta,
+		UploadID:  uuid.NewString(),
+		CreatedAt: time.Now(),
+	}
+}
+
+func (s *UploadSession) expired(timeout time.Duration) bool {
+	return timeout > 0 && time.Since(s.CreatedAt) > timeout
+}
+
+// rollingHashes reconstructs the md5/sha256 hashers from their persisted
+// state, so a chunk appended after a restart keeps hashing from where the
+// previous process left off.
+func (s *UploadSession) rollingHashes() (hash.Hash, hash.Hash, error) {
+	md5h := md5.New()
+	if len(s.MD5State) > 0 {
+		if err := md5h.(encodingBinaryUnmarshaler).UnmarshalBinary(s.MD5State); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sha256h := sha256.New()
+	if len(s.Sha256State) > 0 {
+		if err := sha256h.(encodingBinaryUnmarshaler).UnmarshalBinary(s.Sha256State); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return md5h, sha256h, nil
+}
+
+// encodingBinaryUnmarshaler is implemented by the standard library's md5 and
+// sha256 hashers, letting us checkpoint and resume hash state across chunks.
+type encodingBinaryUnmarshaler interface {
+	UnmarshalBinary([]byte) error
+}
+
+type encodingBinaryMarshaler interface {
+	MarshalBinary() ([]byte, error)
+}
+
+// SessionStore persists UploadSession state so a resumable upload can be
+// resumed after the owning process restarts.
+type SessionStore interface {
+	Save(ctx context.Context, session *UploadSession) error
+	Load(ctx context.Context, sessionID string) (*UploadSession, error)
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// MemorySessionStore is a SessionStore backed by an in-memory map. Sessions
+// do not survive a process restart; use FileSessionStore for that.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*UploadSession
+}
+
+// NewMemorySessionStore creates an empty MemorySessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]*UploadSession)}
+}
+
+func (s *MemorySessionStore) Save(ctx context.Context, session *UploadSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *MemorySessionStore) Load(ctx context.Context, sessionID string) (*UploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, errors.New("upload session not found")
+	}
+	return session, nil
+}
+
+func (s *MemorySessionStore) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// FileSessionStore is a SessionStore that persists each session as a JSON
+// file under Dir, so an upload can be resumed after the process restarts.
+type FileSessionStore struct {
+	Dir string
+}
+
+// NewFileSessionStore creates a FileSessionStore rooted at dir, creating it
+// if it does not already exist.
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSessionStore{Dir: dir}, nil
+}
+
+func (s *FileSessionStore) path(sessionID string) string {
+	return filepath.Join(s.Dir, sessionID+".json")
+}
+
+func (s *FileSessionStore) Save(ctx context.Context, session *UploadSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(session.ID), data, 0o644)
+}
+
+func (s *FileSessionStore) Load(ctx context.Context, sessionID string) (*UploadSession, error) {
+	data, err := os.ReadFile(s.path(sessionID))
+	if err != nil {
+		return nil, err
+	}
+	session := new(UploadSession)
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *FileSessionStore) Delete(ctx context.Context, sessionID string) error {
+	err := os.Remove(s.path(sessionID))
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// CreateUploadSession starts a new resumable upload session for an asset
+// described by meta and persists it to am.SessionStore.
+func (am *AssetUploadManager) CreateUploadSession(ctx context.Context, meta UploadSessionMeta) (*UploadSession, error) {
+	session := newUploadSession(meta)
+	if err := am.sessionStore().Save(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// AppendChunk uploads the next chunk of a resumable session starting at
+// offset. It returns the new offset on success, or ErrOffsetMismatch if
+// offset does not match the session's recorded offset.
+func (am *AssetUploadManager) AppendChunk(ctx context.Context, sessionID string, offset int64, r io.Reader) (int64, error) {
+	session, err := am.sessionStore().Load(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	if session.expired(am.MaxUploadTimeout) {
+		_ = am.sessionStore().Delete(ctx, sessionID)
+		return 0, ErrSessionExpired
+	}
+
+	if offset != session.Offset {
+		return 0, ErrOffsetMismatch{Expected: session.Offset}
+	}
+
+	md5h, sha256h, err := session.rollingHashes()
+	if err != nil {
+		return 0, err
+	}
+
+	chunk, err := io.ReadAll(io.TeeReader(r, io.MultiWriter(md5h, sha256h)))
+	if err != nil {
+		return 0, err
+	}
+
+	newOffset := session.Offset + int64(len(chunk))
+	body, err := am.postChunk(ctx, session, chunk, newOffset)
+	if err != nil {
+		return 0, err
+	}
+
+	session.Offset = newOffset
+	if newOffset >= session.Meta.Size {
+		session.Result = body
+	}
+	session.MD5State, err = md5h.(encodingBinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	session.Sha256State, err = sha256h.(encodingBinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := am.sessionStore().Save(ctx, session); err != nil {
+		return 0, err
+	}
+
+	return newOffset, nil
+}
+
+// FinalizeUpload completes a resumable session once all chunks have been
+// appended and removes it from the session store.
+func (am *AssetUploadManager) FinalizeUpload(ctx context.Context, sessionID string) (*uploader.UploadResult, error) {
+	session, err := am.sessionStore().Load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Offset < session.Meta.Size {
+		return nil, fmt.Errorf("upload incomplete: %d of %d bytes received", session.Offset, session.Meta.Size)
+	}
+
+	if len(session.Result) == 0 {
+		return nil, errors.New("upload session has no result recorded from its completing chunk")
+	}
+
+	result := new(uploader.UploadResult)
+	if err := json.Unmarshal(session.Result, result); err != nil {
+		return nil, err
+	}
+
+	_ = am.sessionStore().Delete(ctx, sessionID)
+	return result, nil
+}
+
+// sessionStore lazily falls back to an in-memory store so callers that never
+// configure one still get working (non-durable) sessions.
+func (am *AssetUploadManager) sessionStore() SessionStore {
+	if am.SessionStore == nil {
+		am.SessionStore = NewMemorySessionStore()
+	}
+	return am.SessionStore
+}
+
+// postChunk sends a single chunk to Cloudinary's chunked upload endpoint,
+// identified by the session's X-Unique-Upload-Id and a Content-Range header
+// covering [session.Offset, newOffset) of the total asset size, and returns
+// the raw response body. Once the Content-Range upper bound reaches
+// session.Meta.Size, that body is the finished asset's full UploadResult
+// JSON, same as a non-chunked upload would return.
+func (am *AssetUploadManager) postChunk(ctx context.Context, session *UploadSession, chunk []byte, newOffset int64) ([]byte, error) {
+	transport, ok := am.backend.(CloudinaryChunkTransport)
+	if !ok {
+		return nil, errors.New("resumable uploads are only supported by the cloudinary backend")
+	}
+
+	formParams, err := transport.chunkSignParams(uploader.UploadParams{
+		PublicID: session.Meta.PublicID,
+		Folder:   session.Meta.Folder,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for key, values := range formParams {
+		_ = writer.WriteField(key, values[0])
+	}
+
+	part, err := writer.CreateFormFile("file", session.Meta.PublicID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(chunk); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, transport.chunkUploadURL(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Unique-Upload-Id", session.UploadID)
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", session.Offset, newOffset-1, session.Meta.Size))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("chunk upload failed with status %s: %s", resp.Status, respBody)
+	}
+
+	return respBody, nil
+}
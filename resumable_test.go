@@ -0,0 +1,124 @@
+package kloudinary
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cloudinary/cloudinary-go/api/uploader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySessionStore_SaveLoadDelete(t *testing.T) {
+	store := NewMemorySessionStore()
+	session := newUploadSession(UploadSessionMeta{PublicID: "p", Size: 10})
+
+	assert.NoError(t, store.Save(context.Background(), session))
+
+	loaded, err := store.Load(context.Background(), session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, session, loaded)
+
+	assert.NoError(t, store.Delete(context.Background(), session.ID))
+	_, err = store.Load(context.Background(), session.ID)
+	assert.Error(t, err)
+}
+
+func TestFileSessionStore_SaveLoadDelete(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir())
+	assert.NoError(t, err)
+
+	session := newUploadSession(UploadSessionMeta{PublicID: "p", Size: 10})
+	assert.NoError(t, store.Save(context.Background(), session))
+
+	loaded, err := store.Load(context.Background(), session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, loaded.ID)
+	assert.Equal(t, session.Meta, loaded.Meta)
+
+	assert.NoError(t, store.Delete(context.Background(), session.ID))
+	assert.NoError(t, store.Delete(context.Background(), session.ID)) // deleting twice is not an error
+
+	_, err = store.Load(context.Background(), session.ID)
+	assert.Error(t, err)
+}
+
+// mockChunkBackend is a Backend that also implements CloudinaryChunkTransport,
+// so AppendChunk/FinalizeUpload can be exercised against a local httptest
+// server instead of Cloudinary's real chunked upload endpoint.
+type mockChunkBackend struct {
+	*LocalBackend
+	url string
+}
+
+func (m *mockChunkBackend) chunkUploadURL() string {
+	return m.url
+}
+
+func (m *mockChunkBackend) chunkSignParams(params uploader.UploadParams) (url.Values, error) {
+	return url.Values{"public_id": []string{params.PublicID}}, nil
+}
+
+func TestAppendChunk_OffsetMismatchDoesNotCallBackend(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	local, err := NewLocalBackend(t.TempDir())
+	assert.NoError(t, err)
+	am, err := NewAssetUploadManagerWithBackend(&mockChunkBackend{LocalBackend: local, url: server.URL})
+	assert.NoError(t, err)
+
+	session, err := am.CreateUploadSession(context.Background(), UploadSessionMeta{Size: 4})
+	assert.NoError(t, err)
+
+	_, err = am.AppendChunk(context.Background(), session.ID, 1, strings.NewReader("x"))
+	assert.Equal(t, ErrOffsetMismatch{Expected: 0}, err)
+	assert.False(t, called)
+}
+
+func TestAppendChunkAndFinalizeUpload_CompletingChunkResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"public_id":"done","bytes":4}`))
+	}))
+	defer server.Close()
+
+	local, err := NewLocalBackend(t.TempDir())
+	assert.NoError(t, err)
+	am, err := NewAssetUploadManagerWithBackend(&mockChunkBackend{LocalBackend: local, url: server.URL})
+	assert.NoError(t, err)
+
+	session, err := am.CreateUploadSession(context.Background(), UploadSessionMeta{Size: 4})
+	assert.NoError(t, err)
+
+	offset, err := am.AppendChunk(context.Background(), session.ID, 0, strings.NewReader("data"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), offset)
+
+	result, err := am.FinalizeUpload(context.Background(), session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", result.PublicID)
+	assert.Equal(t, 4, result.Bytes)
+
+	_, err = am.sessionStore().Load(context.Background(), session.ID)
+	assert.Error(t, err) // finalized sessions are removed from the store
+}
+
+func TestFinalizeUpload_IncompleteSession(t *testing.T) {
+	local, err := NewLocalBackend(t.TempDir())
+	assert.NoError(t, err)
+	am, err := NewAssetUploadManagerWithBackend(local)
+	assert.NoError(t, err)
+
+	session, err := am.CreateUploadSession(context.Background(), UploadSessionMeta{Size: 100})
+	assert.NoError(t, err)
+
+	_, err = am.FinalizeUpload(context.Background(), session.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package kloudinary
+
+// retry.go wraps each upload attempt in a configurable exponential backoff
+// loop, so a transient network or 5xx error does not fail an entire
+// UploadMultipleFiles batch.
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures the retry/backoff loop applied around each upload
+// attempt. The zero value disables retrying: an upload is attempted exactly
+// once regardless of the error it returns.
+type RetryPolicy struct {
+	MaxAttempts    int           // total attempts including the first; <= 1 disables retrying
+	InitialBackoff time.Duration // delay before the second attempt
+	MaxBackoff     time.Duration // delay is capped at this value once reached; <= 0 means uncapped
+	Multiplier     float64       // backoff growth factor between attempts; <= 1 keeps the delay constant
+	Jitter         float64       // randomizes each delay by +/- this fraction (0..1) of itself, to avoid thundering herds across a batch
+}
+
+// retryPolicy returns am.RetryPolicy normalized so MaxAttempts/Multiplier
+// always have a usable value.
+func (am *AssetUploadManager) retryPolicy() RetryPolicy {
+	p := am.RetryPolicy
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = 1
+	}
+	return p
+}
+
+// run invokes attempt up to p.MaxAttempts times, sleeping with exponential
+// backoff (plus jitter) between attempts. It returns the last error once
+// attempts are exhausted or attempt returns a permanent error (see
+// isPermanent), and ctx.Err() if ctx is cancelled while waiting.
+func (p RetryPolicy) run(ctx context.Context, attempt func(n int) error) error {
+	backoff := p.InitialBackoff
+
+	var err error
+	for n := 0; n < p.MaxAttempts; n++ {
+		if n > 0 {
+			if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+				backoff = p.MaxBackoff
+			}
+
+			delay := backoff
+			if p.Jitter > 0 {
+				delay = jitterDuration(delay, p.Jitter)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+
+			if p.Multiplier > 1 {
+				backoff = time.Duration(float64(backoff) * p.Multiplier)
+			}
+		}
+
+		if err = attempt(n); err == nil {
+			return nil
+		}
+		if isPermanent(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isPermanent reports whether err describes a rejection that will
+// deterministically recur on every retry of the same input (a MIME policy
+// violation, an oversized asset), as opposed to a transient failure such as
+// a network timeout. Permanent errors short-circuit the retry loop instead
+// of burning the full backoff schedule on an attempt that can never succeed.
+func isPermanent(err error) bool {
+	var mimeErr ErrMimeMismatch
+	var sizeErr ErrMaxAssetSizeExceeded
+	return errors.As(err, &mimeErr) || errors.As(err, &sizeErr)
+}
+
+// jitterDuration randomizes d by +/- fraction of itself (fraction clamped to
+// [0, 1]).
+func jitterDuration(d time.Duration, fraction float64) time.Duration {
+	if fraction > 1 {
+		fraction = 1
+	}
+	delta := float64(d) * fraction
+	return d + time.Duration((rand.Float64()*2-1)*delta)
+}
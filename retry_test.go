@@ -0,0 +1,77 @@
+package kloudinary
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_NormalizedZeroValueAttemptsOnce(t *testing.T) {
+	am := &AssetUploadManager{}
+	attempts := 0
+	err := am.retryPolicy().run(context.Background(), func(n int) error {
+		attempts++
+		return errors.New("boom")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicy_RetriesTransientErrorsUntilSuccess(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	attempts := 0
+	err := p.run(context.Background(), func(n int) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryPolicy_StopsOnPermanentError(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}
+	attempts := 0
+	err := p.run(context.Background(), func(n int) error {
+		attempts++
+		return ErrMimeMismatch{Declared: "image/png", Detected: "application/zip"}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicy_ExhaustsAttemptsAndReturnsLastError(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	attempts := 0
+	err := p.run(context.Background(), func(n int) error {
+		attempts++
+		return errors.New("still broken")
+	})
+	assert.EqualError(t, err, "still broken")
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryPolicy_StopsWhenContextCancelled(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := p.run(ctx, func(n int) error {
+		attempts++
+		return errors.New("transient")
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestIsPermanent(t *testing.T) {
+	assert.True(t, isPermanent(ErrMimeMismatch{}))
+	assert.True(t, isPermanent(ErrMaxAssetSizeExceeded{Max: 10}))
+	assert.False(t, isPermanent(errors.New("network timeout")))
+}
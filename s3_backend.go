@@ -0,0 +1,511 @@
+package kloudinary
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/api/uploader"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultS3PartSize    = 8 * 1024 * 1024 // 8 MB, S3's minimum part size for all but the last part
+	defaultS3Concurrency = 4
+)
+
+// S3Backend is the Backend implementation for S3-compatible object storage.
+// Objects are written via S3 multipart upload, split into PartSize chunks
+// with up to Concurrency parts in flight at once.
+type S3Backend struct {
+	Endpoint        string // e.g. https://s3.us-east-1.amazonaws.com, or a compatible provider's endpoint
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PartSize        int64
+	Concurrency     int
+	Client          *http.Client
+}
+
+// NewS3Backend creates an S3Backend with the default part size and
+// concurrency; override S3Backend.PartSize/Concurrency afterwards to tune them.
+func NewS3Backend(endpoint, region, bucket, accessKeyID, secretAccessKey string) *S3Backend {
+	return &S3Backend{
+		Endpoint:        strings.TrimSuffix(endpoint, "/"),
+		Region:          region,
+		Bucket:          bucket,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		PartSize:        defaultS3PartSize,
+		Concurrency:     defaultS3Concurrency,
+	}
+}
+
+func (b *S3Backend) client() *http.Client {
+	if b.Client != nil {
+		return b.Client
+	}
+	return http.DefaultClient
+}
+
+func (b *S3Backend) objectKey(params uploader.UploadParams) string {
+	publicID := params.PublicID
+	if publicID == "" {
+		publicID = uuid.NewString()
+	}
+	if params.Folder != "" {
+		return params.Folder + "/" + publicID
+	}
+	return publicID
+}
+
+func (b *S3Backend) objectURL(key string) string {
+	return fmt.Sprintf("%s/%s/%s", b.Endpoint, b.Bucket, key)
+}
+
+type s3Part struct {
+	PartNumber int
+	ETag       string
+}
+
+func (b *S3Backend) Upload(ctx context.Context, file interface{}, params uploader.UploadParams) (*uploader.UploadResult, error) {
+	var r io.Reader
+	switch v := file.(type) {
+	case string:
+		fh, err := os.Open(v)
+		if err != nil {
+			return nil, err
+		}
+		defer fh.Close()
+		r = fh
+	case io.Reader:
+		r = v
+	default:
+		return nil, errors.New("s3 backend: unsupported file type")
+	}
+
+	key := b.objectKey(params)
+
+	uploadID, err := b.createMultipartUpload(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, size, err := b.uploadParts(ctx, key, uploadID, r)
+	if err != nil {
+		_ = b.abortMultipartUpload(ctx, key, uploadID)
+		return nil, err
+	}
+
+	if err := b.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		return nil, err
+	}
+
+	publicID := key
+
+	return &uploader.UploadResult{
+		PublicID:  publicID,
+		Bytes:     int(size),
+		URL:       b.objectURL(key),
+		SecureURL: b.objectURL(key),
+	}, nil
+}
+
+// uploadParts reads r in PartSize chunks and uploads up to Concurrency of
+// them at a time, returning the completed parts sorted by part number.
+func (b *S3Backend) uploadParts(ctx context.Context, key, uploadID string, r io.Reader) ([]s3Part, int64, error) {
+	partSize := b.PartSize
+	if partSize <= 0 {
+		partSize = defaultS3PartSize
+	}
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultS3Concurrency
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		parts    []s3Part
+		total    int64
+		firstErr error
+	)
+
+	for partNumber := 1; ; partNumber++ {
+		mu.Lock()
+		stop := firstErr != nil
+		mu.Unlock()
+		if stop {
+			break
+		}
+
+		buf := make([]byte, partSize)
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.ErrUnexpectedEOF && readErr != io.EOF {
+			return nil, 0, readErr
+		}
+		if n == 0 {
+			break
+		}
+		data := buf[:n]
+		total += int64(n)
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(partNumber int, data []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			etag, uploadErr := b.uploadPart(ctx, key, uploadID, partNumber, data)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if uploadErr != nil {
+				if firstErr == nil {
+					firstErr = uploadErr
+				}
+				return
+			}
+			parts = append(parts, s3Part{PartNumber: partNumber, ETag: etag})
+		}(partNumber, data)
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, 0, firstErr
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, total, nil
+}
+
+func (b *S3Backend) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	u := fmt.Sprintf("%s/%s/%s?uploads", b.Endpoint, b.Bucket, key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := b.signRequest(req, hashHex(nil)); err != nil {
+		return "", err
+	}
+
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("s3 backend: create multipart upload failed with status %s", resp.Status)
+	}
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.UploadID, nil
+}
+
+func (b *S3Backend) uploadPart(ctx context.Context, key, uploadID string, partNumber int, data []byte) (string, error) {
+	u := fmt.Sprintf("%s/%s/%s?partNumber=%d&uploadId=%s", b.Endpoint, b.Bucket, key, partNumber, url.QueryEscape(uploadID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(data))
+	if err := b.signRequest(req, hashHex(data)); err != nil {
+		return "", err
+	}
+
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("s3 backend: upload part %d failed with status %s", partNumber, resp.Status)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("s3 backend: upload part %d returned no ETag", partNumber)
+	}
+	return etag, nil
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+func (b *S3Backend) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []s3Part) error {
+	body := s3CompleteMultipartUpload{}
+	for _, p := range parts {
+		body.Parts = append(body.Parts, s3CompletedPart{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+	payload, err := xml.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	u := fmt.Sprintf("%s/%s/%s?uploadId=%s", b.Endpoint, b.Bucket, key, url.QueryEscape(uploadID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(payload))
+	if err := b.signRequest(req, hashHex(payload)); err != nil {
+		return err
+	}
+
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 backend: complete multipart upload failed with status %s", resp.Status)
+	}
+	return nil
+}
+
+func (b *S3Backend) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	u := fmt.Sprintf("%s/%s/%s?uploadId=%s", b.Endpoint, b.Bucket, key, url.QueryEscape(uploadID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	if err := b.signRequest(req, hashHex(nil)); err != nil {
+		return err
+	}
+
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return nil
+}
+
+func (b *S3Backend) Destroy(ctx context.Context, publicID string, resourceType string) (*uploader.DestroyResult, error) {
+	u := fmt.Sprintf("%s/%s/%s", b.Endpoint, b.Bucket, publicID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.signRequest(req, hashHex(nil)); err != nil {
+		return nil, err
+	}
+
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("s3 backend: destroy failed with status %s", resp.Status)
+	}
+	return &uploader.DestroyResult{Result: "ok"}, nil
+}
+
+func (b *S3Backend) Transform(ctx context.Context, publicID string, resourceType string, t Transformation) (string, error) {
+	return "", errors.New("s3 backend: transformations are not supported")
+}
+
+// SignedURL returns a presigned GET URL for publicID, valid until expiresAt.
+// S3 has no notion of delivery-time transformations, so t is ignored.
+func (b *S3Backend) SignedURL(ctx context.Context, publicID string, resourceType string, t Transformation, expiresAt time.Time) (string, error) {
+	now := time.Now().UTC()
+	expiresIn := int64(expiresAt.Sub(now).Seconds())
+	if expiresIn <= 0 {
+		return "", errors.New("s3 backend: expiresAt must be in the future")
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, b.Region)
+
+	u, err := url.Parse(fmt.Sprintf("%s/%s/%s", b.Endpoint, b.Bucket, publicID))
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", b.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(expiresIn, 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQueryString(query),
+		fmt.Sprintf("host:%s\n", u.Host),
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hashBytes([]byte(canonicalRequest))),
+	}, "\n")
+
+	signingKey := sigV4SigningKey(b.SecretAccessKey, dateStamp, b.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	query.Set("X-Amz-Signature", signature)
+	u.RawQuery = canonicalQueryString(query)
+
+	return u.String(), nil
+}
+
+// signRequest signs req in place using AWS Signature Version 4.
+func (b *S3Backend) signRequest(req *http.Request, payloadHash string) error {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+
+	canonicalHeaders, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, b.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hashBytes([]byte(canonicalRequest))),
+	}, "\n")
+
+	signingKey := sigV4SigningKey(b.SecretAccessKey, dateStamp, b.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.AccessKeyID, scope, signedHeaders, signature,
+	))
+
+	return nil
+}
+
+func canonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{
+		"host":                 req.Host,
+		"x-amz-content-sha256": req.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date":           req.Header.Get("X-Amz-Date"),
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name)
+		canonical.WriteString(":")
+		canonical.WriteString(headers[name])
+		canonical.WriteString("\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+func canonicalQueryString(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range values[k] {
+			parts = append(parts, sigV4URIEncode(k)+"="+sigV4URIEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// sigV4URIEncode percent-encodes s per the SigV4 spec
+// (https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html#create-canonical-request):
+// every octet outside A-Z a-z 0-9 - _ . ~ is escaped as %XX, including
+// space as %20 rather than url.QueryEscape's form-encoded "+".
+func sigV4URIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hashBytes(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+func hashHex(data []byte) string {
+	return hex.EncodeToString(hashBytes(data))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sigV4SigningKey(secret, dateStamp, region, service string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secret), dateStamp)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, service)
+	return hmacSHA256(kService, "aws4_request")
+}
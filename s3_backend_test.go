@@ -0,0 +1,45 @@
+package kloudinary
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigV4URIEncode(t *testing.T) {
+	// AWS SigV4 requires RFC 3986 percent-encoding: space must become %20,
+	// not the "+" that url.QueryEscape would produce.
+	assert.Equal(t, "a%20b", sigV4URIEncode("a b"))
+	assert.Equal(t, "a-b_c.d~e", sigV4URIEncode("a-b_c.d~e"))
+	assert.Equal(t, "%2F", sigV4URIEncode("/"))
+	assert.Equal(t, "key%3Dvalue", sigV4URIEncode("key=value"))
+}
+
+func TestCanonicalQueryString(t *testing.T) {
+	values := url.Values{
+		"X-Amz-Expires": []string{"300"},
+		"prefix":        []string{"a b"},
+	}
+	got := canonicalQueryString(values)
+	assert.Equal(t, "X-Amz-Expires=300&prefix=a%20b", got)
+}
+
+func TestS3Backend_SignedURLQueryMatchesSignedCanonicalForm(t *testing.T) {
+	b := NewS3Backend("https://s3.example.com", "us-east-1", "bucket", "AKIDEXAMPLE", "secret")
+
+	signedURL, err := b.SignedURL(context.Background(), "a key.png", "", NewTransformation(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	u, err := url.Parse(signedURL)
+	assert.NoError(t, err)
+
+	// The query string actually sent on the wire must be exactly what was
+	// signed, so it must not contain "+" for space the way url.Values.Encode
+	// would have produced.
+	assert.False(t, strings.Contains(u.RawQuery, "+"))
+	assert.Equal(t, canonicalQueryString(u.Query()), u.RawQuery)
+}
@@ -0,0 +1,142 @@
+package kloudinary
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Overlay layers another asset on top of the base one via Cloudinary's l_
+// (layer) transformation component.
+type Overlay struct {
+	PublicID string // the asset to overlay
+	Gravity  string // placement relative to the base asset, e.g. "north_east"
+	X, Y     int    // pixel offset from Gravity
+}
+
+func (o Overlay) String() string {
+	parts := []string{"l_" + o.PublicID}
+	if o.Gravity != "" {
+		parts = append(parts, "g_"+o.Gravity)
+	}
+	if o.X != 0 {
+		parts = append(parts, fmt.Sprintf("x_%d", o.X))
+	}
+	if o.Y != 0 {
+		parts = append(parts, fmt.Sprintf("y_%d", o.Y))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Effect applies a named Cloudinary effect (e.g. "sepia", "blur:300") via
+// the e_ transformation component.
+type Effect struct {
+	Name string
+}
+
+func (e Effect) String() string {
+	return "e_" + e.Name
+}
+
+// Transformation is a fluent, typed builder for a Cloudinary delivery-URL
+// transformation. The zero value (equivalently NewTransformation()) applies
+// no transformation. Values are immutable: every setter returns a new
+// Transformation, so a base Transformation can be safely reused and
+// specialized from multiple call sites.
+type Transformation struct {
+	width    int
+	height   int
+	crop     string
+	gravity  string
+	format   string
+	quality  string
+	overlays []Overlay
+	effects  []Effect
+}
+
+// NewTransformation starts a new, empty Transformation.
+func NewTransformation() Transformation {
+	return Transformation{}
+}
+
+// Resize sets the target width and height, e.g. NewTransformation().Resize(800, 600).
+func (t Transformation) Resize(width, height int) Transformation {
+	t.width, t.height = width, height
+	return t
+}
+
+// Crop sets the crop mode (e.g. "fill", "fit", "thumb").
+func (t Transformation) Crop(mode string) Transformation {
+	t.crop = mode
+	return t
+}
+
+// Gravity sets the focal point crop/resize is anchored to (e.g. "face", "north_east").
+func (t Transformation) Gravity(gravity string) Transformation {
+	t.gravity = gravity
+	return t
+}
+
+// Format sets the delivered file format (e.g. "webp", "auto").
+func (t Transformation) Format(format string) Transformation {
+	t.format = format
+	return t
+}
+
+// Quality sets the delivered compression quality (e.g. "auto", "80").
+func (t Transformation) Quality(quality string) Transformation {
+	t.quality = quality
+	return t
+}
+
+// Overlay appends an additional layer on top of the base asset.
+func (t Transformation) Overlay(o Overlay) Transformation {
+	t.overlays = append(append([]Overlay{}, t.overlays...), o)
+	return t
+}
+
+// Effect appends an additional named effect.
+func (t Transformation) Effect(e Effect) Transformation {
+	t.effects = append(append([]Effect{}, t.effects...), e)
+	return t
+}
+
+// String renders t as a Cloudinary transformation string: resize/crop/
+// gravity/quality/format share one comma-separated component, followed by
+// one slash-separated component per overlay, then one per effect. Overlays
+// keep the order they were added in, as do effects; all overlays come before
+// all effects, whatever order the two were added in.
+func (t Transformation) String() string {
+	var components []string
+
+	var main []string
+	if t.width > 0 {
+		main = append(main, fmt.Sprintf("w_%d", t.width))
+	}
+	if t.height > 0 {
+		main = append(main, fmt.Sprintf("h_%d", t.height))
+	}
+	if t.crop != "" {
+		main = append(main, "c_"+t.crop)
+	}
+	if t.gravity != "" {
+		main = append(main, "g_"+t.gravity)
+	}
+	if t.quality != "" {
+		main = append(main, "q_"+t.quality)
+	}
+	if t.format != "" {
+		main = append(main, "f_"+t.format)
+	}
+	if len(main) > 0 {
+		components = append(components, strings.Join(main, ","))
+	}
+
+	for _, o := range t.overlays {
+		components = append(components, o.String())
+	}
+	for _, e := range t.effects {
+		components = append(components, e.String())
+	}
+
+	return strings.Join(components, "/")
+}
@@ -0,0 +1,41 @@
+package kloudinary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformation_ZeroValueRendersEmpty(t *testing.T) {
+	assert.Equal(t, "", NewTransformation().String())
+}
+
+func TestTransformation_MainComponent(t *testing.T) {
+	tr := NewTransformation().Resize(800, 600).Crop("fill").Gravity("face").Quality("auto").Format("webp")
+	assert.Equal(t, "w_800,h_600,c_fill,g_face,q_auto,f_webp", tr.String())
+}
+
+func TestTransformation_OverlayAndEffectComponents(t *testing.T) {
+	tr := NewTransformation().
+		Resize(100, 100).
+		Overlay(Overlay{PublicID: "logo", Gravity: "north_east", X: 10, Y: 20}).
+		Effect(Effect{Name: "sepia"})
+
+	assert.Equal(t, "w_100,h_100/l_logo,g_north_east,x_10,y_20/e_sepia", tr.String())
+}
+
+func TestTransformation_SettersReturnIndependentValues(t *testing.T) {
+	base := NewTransformation().Quality("auto")
+	specialized := base.Format("webp")
+
+	assert.Equal(t, "q_auto", base.String())
+	assert.Equal(t, "q_auto,f_webp", specialized.String())
+}
+
+func TestTransformation_MultipleOverlaysPreserveOrder(t *testing.T) {
+	tr := NewTransformation().
+		Overlay(Overlay{PublicID: "first"}).
+		Overlay(Overlay{PublicID: "second"})
+
+	assert.Equal(t, "l_first/l_second", tr.String())
+}